@@ -0,0 +1,33 @@
+package cron
+
+import "time"
+
+// ConstantDelaySchedule represents a simple recurring duty cycle, such as
+// "Every 5 minutes". It doesn't support jobs more frequent than once a
+// second. It's the schedule produced by the "@every <duration>"
+// descriptor.
+//
+// Unlike SpecSchedule, it has no fixed activation grid: Next and Prev are
+// simply t+Delay and t-Delay, each relative to whatever instant they're
+// given, rather than inverse walks over a shared set of activation
+// instants. So Prev(Next(t)) returns t itself (truncated to the second),
+// and so does Prev(Next(t)+1ns): Delay earlier than Next(t), not Next(t)
+// as SpecSchedule's Prev would return.
+type ConstantDelaySchedule struct {
+	Delay time.Duration
+}
+
+// Next returns the next time this schedule is activated, strictly after
+// the given time. The schedule has no reference instant of its own, so
+// this is simply t+Delay, truncated to the second.
+func (s ConstantDelaySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.Delay - time.Duration(t.Nanosecond())*time.Nanosecond)
+}
+
+// Prev returns the time this schedule would have had to activate at in
+// order for Next to return t, strictly before the given time: simply
+// t-Delay, truncated to the second. It is the inverse of Next, not a walk
+// over the same activation grid Next produces (see ConstantDelaySchedule).
+func (s ConstantDelaySchedule) Prev(t time.Time) time.Time {
+	return t.Add(-s.Delay - time.Duration(t.Nanosecond())*time.Nanosecond)
+}
@@ -0,0 +1,39 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstantDelayNext(t *testing.T) {
+	cd := ConstantDelaySchedule{Delay: 90 * time.Minute}
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	got := cd.Next(start)
+	want := time.Date(2026, time.March, 1, 1, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestConstantDelayPrevIsNotGridWalk(t *testing.T) {
+	// ConstantDelaySchedule has no activation grid of its own: Prev is
+	// t-Delay, not an inverse walk back to Next(t) (see its doc comment).
+	cd := ConstantDelaySchedule{Delay: 90 * time.Minute}
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	next := cd.Next(start)
+	got := cd.Prev(next.Add(time.Nanosecond))
+	want := start
+	if !got.Equal(want) {
+		t.Errorf("Prev(Next(t)+1ns) = %s, want %s (t, not Next(t))", got, want)
+	}
+}
+
+func TestConstantDelayPrev(t *testing.T) {
+	cd := ConstantDelaySchedule{Delay: 90 * time.Minute}
+	base := time.Date(2026, time.March, 1, 1, 30, 0, 0, time.UTC)
+	got := cd.Prev(base)
+	want := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Prev() = %s, want %s", got, want)
+	}
+}
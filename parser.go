@@ -0,0 +1,602 @@
+package cron
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseOption configures which of the seven crontab positions
+// (Second Minute Hour Dom Month Dow Year) a Parser expects in its input.
+// A field not included in the option set is treated as unrestricted ("*")
+// rather than being read from the spec string.
+type ParseOption int
+
+const (
+	Second ParseOption = 1 << iota
+	Minute
+	Hour
+	Dom
+	Month
+	Dow
+	Year
+
+	// DowOptional makes the Dow field optional: the Parser also accepts
+	// specs one field short of its configured count, filling Dow with
+	// "*" itself. At most one field may be made optional this way.
+	DowOptional
+
+	// Descriptor enables the "@every <duration>" and
+	// "@hourly"/"@daily"/... shorthand forms.
+	Descriptor
+)
+
+// places lists the seven crontab positions a Parser can be configured to
+// expect, in the fixed left-to-right order they appear in a spec string.
+var places = []ParseOption{Second, Minute, Hour, Dom, Month, Dow, Year}
+
+// fieldDefaults mirrors places: the value substituted for a field the
+// Parser wasn't configured to read from the input.
+var fieldDefaults = []string{"*", "*", "*", "*", "*", "*", "*"}
+
+// descriptors maps the Cron/Quartz-style shorthand descriptors to the
+// 5-field (Standard) spec they expand to. "@every" is handled separately
+// since it takes a duration rather than expanding to a fixed spec.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parser parses crontab specs into a Schedule according to a fixed set of
+// expected fields. Build one with NewParser, or use the Standard or
+// SecondsOptional presets.
+type Parser struct {
+	options ParseOption
+}
+
+// NewParser builds a Parser that expects exactly the fields named in
+// options, read from the spec string in the fixed Second/Minute/Hour/
+// Dom/Month/Dow/Year order (skipping whichever aren't included).
+func NewParser(options ParseOption) Parser {
+	if options&DowOptional > 0 {
+		options |= Dow
+	}
+	return Parser{options}
+}
+
+// Standard is a Parser preset for the traditional 5-field POSIX crontab:
+// "min hour dom month dow".
+var Standard = NewParser(Minute | Hour | Dom | Month | Dow | Descriptor)
+
+// SecondsOptional is a Parser preset that accepts crontab specs with 5
+// fields (the Standard POSIX form), 6 fields (Standard plus a leading
+// Second), or 7 fields (also trailing Year), dispatching to the matching
+// NewParser configuration based on however many fields the spec actually
+// has.
+var SecondsOptional = secondsOptionalParser{}
+
+type secondsOptionalParser struct{}
+
+func (secondsOptionalParser) Parse(spec string) (Schedule, error) {
+	return secondsOptionalParser{}.parse(spec, "")
+}
+
+// ParseWithKey is Parse, additionally resolving "H" tokens against key
+// (see Parser.ParseWithKey).
+func (secondsOptionalParser) ParseWithKey(spec, key string) (Schedule, error) {
+	if key == "" {
+		return nil, fmt.Errorf("cron: ParseWithKey requires a non-empty key: %q", spec)
+	}
+	return secondsOptionalParser{}.parse(spec, key)
+}
+
+func (secondsOptionalParser) parse(spec, key string) (Schedule, error) {
+	trimmed, _, err := splitTZPrefix(spec)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(trimmed, "@") {
+		return Standard.Parse(spec)
+	}
+
+	switch n := len(strings.Fields(trimmed)); n {
+	case 5:
+		return NewParser(Minute | Hour | Dom | Month | Dow | Descriptor).parse(spec, key)
+	case 6:
+		return NewParser(Second | Minute | Hour | Dom | Month | Dow | Descriptor).parse(spec, key)
+	case 7:
+		return NewParser(Second | Minute | Hour | Dom | Month | Dow | Year | Descriptor).parse(spec, key)
+	default:
+		return nil, fmt.Errorf("cron: expected 5, 6, or 7 fields, found %d: %q", n, spec)
+	}
+}
+
+// Parse returns a new Schedule parsed from a descriptor ("@every 5m",
+// "@hourly", "@daily", ...) or a traditional crontab specification,
+// accepting 5, 6, or 7 fields (see SecondsOptional). Either form may be
+// prefixed with "CRON_TZ=<name> " (or the legacy "TZ=<name> ") to
+// override the schedule's time zone, e.g.
+// "CRON_TZ=America/Chicago 30 9 * * 1-5".
+//
+// The day-of-month field additionally accepts the Quartz extensions "L"
+// (last day of the month), "L-n" (n days before the last day), and "nW"
+// (the weekday nearest day n, without crossing a month boundary). The
+// day-of-week field additionally accepts "5L" (the last Friday of the
+// month) and "MON#2" (the second Monday of the month) style tokens.
+//
+// Any field may additionally accept the Jenkins-style "H" token in place
+// of a concrete value or range; see ParseWithKey.
+func Parse(spec string) (Schedule, error) {
+	return SecondsOptional.Parse(spec)
+}
+
+// ParseWithKey is Parse, except it also resolves "H" tokens (see
+// ParseWithKey on Parser) against key.
+func ParseWithKey(spec, key string) (Schedule, error) {
+	return SecondsOptional.ParseWithKey(spec, key)
+}
+
+// Parse parses spec according to p's configured fields.
+func (p Parser) Parse(spec string) (Schedule, error) {
+	return p.parse(spec, "")
+}
+
+// ParseWithKey parses spec according to p's configured fields, same as
+// Parse, but additionally resolves the Jenkins-style "H" token against
+// key: "H" picks a single value spread evenly across the field's range,
+// "H(lo-hi)" picks one spread across the sub-range lo-hi, and "H/step" is
+// "*/step" with its starting offset likewise spread across [0, step)
+// instead of always landing on the field's minimum. The spread is a hash
+// of key, so the same key always resolves to the same value - a fleet of
+// otherwise-identical jobs given distinct keys (e.g. their own hostnames)
+// spreads itself across the allowed range instead of waking up all at
+// once. A spec containing an "H" token parsed through Parse (key == "")
+// is rejected.
+func (p Parser) ParseWithKey(spec, key string) (Schedule, error) {
+	if key == "" {
+		return nil, fmt.Errorf("cron: ParseWithKey requires a non-empty key: %q", spec)
+	}
+	return p.parse(spec, key)
+}
+
+// parse is the shared implementation behind Parse and ParseWithKey; key is
+// "" for the former, used to resolve any "H" tokens for the latter.
+func (p Parser) parse(spec, key string) (Schedule, error) {
+	spec, loc, err := splitTZPrefix(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.options&Descriptor > 0 && strings.HasPrefix(spec, "@") {
+		return parseDescriptor(spec, loc)
+	}
+
+	fields, err := p.normalizeFields(strings.Fields(spec))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &SpecSchedule{Location: loc}
+	if schedule.Second, err = parseField(fields[0], seconds, key); err != nil {
+		return nil, err
+	}
+	if schedule.Minute, err = parseField(fields[1], minutes, key); err != nil {
+		return nil, err
+	}
+	if schedule.Hour, err = parseField(fields[2], hours, key); err != nil {
+		return nil, err
+	}
+	if schedule.Dom, err = parseDomField(fields[3], schedule, key); err != nil {
+		return nil, err
+	}
+	if schedule.Month, err = parseField(fields[4], months, key); err != nil {
+		return nil, err
+	}
+	if schedule.Dow, err = parseDowField(fields[5], schedule, key); err != nil {
+		return nil, err
+	}
+	if schedule.Year, err = parseYearField(fields[6], years, key); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// normalizeFields expands fields (as split from the spec, containing only
+// the positions p was configured to expect) into the full 7-element
+// Second/Minute/Hour/Dom/Month/Dow/Year slice Parse needs, filling
+// whatever p wasn't configured to read with fieldDefaults.
+func (p Parser) normalizeFields(fields []string) ([]string, error) {
+	max := 0
+	for _, place := range places {
+		if p.options&place > 0 {
+			max++
+		}
+	}
+	min := max
+	if p.options&DowOptional > 0 {
+		min--
+	}
+
+	if len(fields) < min || len(fields) > max {
+		if min == max {
+			return nil, fmt.Errorf("cron: expected exactly %d fields, found %d: %q", max, len(fields), strings.Join(fields, " "))
+		}
+		return nil, fmt.Errorf("cron: expected %d to %d fields, found %d: %q", min, max, len(fields), strings.Join(fields, " "))
+	}
+
+	if p.options&DowOptional > 0 && len(fields) == min {
+		// Dow was left out: splice its default in ahead of whatever
+		// configured field comes after it (Year, if any).
+		dowIndex := 0
+		for _, place := range places {
+			if place == Dow {
+				break
+			}
+			if p.options&place > 0 {
+				dowIndex++
+			}
+		}
+		withDow := make([]string, 0, len(fields)+1)
+		withDow = append(withDow, fields[:dowIndex]...)
+		withDow = append(withDow, fieldDefaults[5])
+		fields = append(withDow, fields[dowIndex:]...)
+	}
+
+	expanded := make([]string, len(places))
+	copy(expanded, fieldDefaults)
+	n := 0
+	for i, place := range places {
+		if p.options&place > 0 {
+			expanded[i] = fields[n]
+			n++
+		}
+	}
+	return expanded, nil
+}
+
+// splitTZPrefix strips a leading "CRON_TZ=<name> " or "TZ=<name> " prefix
+// off of spec, returning the remaining spec and the named location (or
+// time.Local if there was no prefix).
+func splitTZPrefix(spec string) (string, *time.Location, error) {
+	var tzName string
+	switch {
+	case strings.HasPrefix(spec, "CRON_TZ="):
+		tzName = strings.TrimPrefix(spec, "CRON_TZ=")
+	case strings.HasPrefix(spec, "TZ="):
+		tzName = strings.TrimPrefix(spec, "TZ=")
+	default:
+		return spec, time.Local, nil
+	}
+
+	parts := strings.SplitN(tzName, " ", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("cron: missing fields after time zone: %q", spec)
+	}
+	loc, err := time.LoadLocation(parts[0])
+	if err != nil {
+		return "", nil, fmt.Errorf("cron: bad time zone %q: %s", parts[0], err)
+	}
+	return parts[1], loc, nil
+}
+
+// parseDescriptor handles the "@every <duration>" and "@hourly"/"@daily"/...
+// shorthand forms.
+func parseDescriptor(spec string, loc *time.Location) (Schedule, error) {
+	if strings.HasPrefix(spec, "@every ") {
+		delay, err := time.ParseDuration(strings.TrimPrefix(spec, "@every "))
+		if err != nil {
+			return nil, fmt.Errorf("cron: failed to parse duration %q: %s", spec, err)
+		}
+		return ConstantDelaySchedule{Delay: delay}, nil
+	}
+
+	expanded, ok := descriptors[spec]
+	if !ok {
+		return nil, fmt.Errorf("cron: unrecognized descriptor: %q", spec)
+	}
+	schedule, err := Standard.Parse(expanded)
+	if err != nil {
+		return nil, err
+	}
+	schedule.(*SpecSchedule).Location = loc
+	return schedule, nil
+}
+
+// parseDomField parses the day-of-month field, pulling out the Quartz "L",
+// "L-n" and "nW" tokens (which can't be represented in a bit map) onto
+// schedule and passing whatever's left through the standard range syntax.
+func parseDomField(field string, schedule *SpecSchedule, key string) (uint64, error) {
+	var rest []string
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case part == "L":
+			schedule.HasDomLast = true
+			schedule.DomLastOffset = 0
+		case strings.HasPrefix(part, "L-"):
+			n, err := mustParseInt(part[len("L-"):])
+			if err != nil {
+				return 0, fmt.Errorf("cron: bad day-of-month token %q: %s", part, err)
+			}
+			schedule.HasDomLast = true
+			schedule.DomLastOffset = int(n)
+		case strings.HasSuffix(part, "W"):
+			n, err := mustParseInt(strings.TrimSuffix(part, "W"))
+			if err != nil {
+				return 0, fmt.Errorf("cron: bad day-of-month token %q: %s", part, err)
+			}
+			schedule.HasDomNearest = true
+			schedule.DomNearestWeekday = int(n)
+		default:
+			rest = append(rest, part)
+		}
+	}
+	if len(rest) == 0 {
+		return 0, nil
+	}
+	return parseField(strings.Join(rest, ","), dom, key)
+}
+
+// parseDowField parses the day-of-week field, pulling out the Quartz "5L"
+// and "MON#2" tokens (which can't be represented in a bit map) onto
+// schedule and passing whatever's left through the standard range syntax.
+func parseDowField(field string, schedule *SpecSchedule, key string) (uint64, error) {
+	var rest []string
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case strings.HasSuffix(part, "L") && part != "L":
+			wd, err := parseIntOrName(strings.TrimSuffix(part, "L"), dow.names)
+			if err != nil {
+				return 0, fmt.Errorf("cron: bad day-of-week token %q: %s", part, err)
+			}
+			schedule.HasDowLast = true
+			schedule.DowLastOfMonth = uint8(wd)
+		case strings.Contains(part, "#"):
+			pieces := strings.SplitN(part, "#", 2)
+			wd, err := parseIntOrName(pieces[0], dow.names)
+			if err != nil {
+				return 0, fmt.Errorf("cron: bad day-of-week token %q: %s", part, err)
+			}
+			n, err := mustParseInt(pieces[1])
+			if err != nil {
+				return 0, fmt.Errorf("cron: bad day-of-week token %q: %s", part, err)
+			}
+			schedule.HasDowNth = true
+			schedule.DowNthOfMonth.Dow = uint8(wd)
+			schedule.DowNthOfMonth.N = uint8(n)
+		default:
+			rest = append(rest, part)
+		}
+	}
+	if len(rest) == 0 {
+		return 0, nil
+	}
+	return parseField(strings.Join(rest, ","), dow, key)
+}
+
+// parseField parses a single cron field (e.g. "1,5-10/2,*") into a bit map,
+// setting wildcardBit if the field was unrestricted ("*" or "?") so that
+// dayMatches can tell a bare wildcard from an explicit range.
+func parseField(field string, r bounds, key string) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		start, end, step, isStar, err := resolveExpr(expr, r, key)
+		if err != nil {
+			return 0, err
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << i
+		}
+		if isStar {
+			bits |= 1 << wildcardBit
+		}
+	}
+	return bits, nil
+}
+
+// parseYearField parses a single cron field against the wide Year bounds,
+// spread across the three uint64 words of a year bit map. Year has no
+// dayMatches-style dual-restriction semantics, so unlike parseField it
+// doesn't need a wildcard marker bit.
+func parseYearField(field string, r bounds, key string) ([3]uint64, error) {
+	var bits [3]uint64
+	for _, expr := range strings.Split(field, ",") {
+		start, end, step, _, err := resolveExpr(expr, r, key)
+		if err != nil {
+			return bits, err
+		}
+		for i := start; i <= end; i += step {
+			bits[i/64] |= 1 << (i % 64)
+		}
+	}
+	return bits, nil
+}
+
+// resolveExpr parses a single comma-separated range expression, handling
+// the Jenkins-style "H" hash token (see parseHExpr) before falling back to
+// the ordinary start[-end][/step] syntax parsed by parseRangeExpr.
+func resolveExpr(expr string, r bounds, key string) (start, end, step uint, isStar bool, err error) {
+	if expr == "H" || strings.HasPrefix(expr, "H(") || strings.HasPrefix(expr, "H/") {
+		start, end, step, err = parseHExpr(expr, r, key)
+		return start, end, step, false, err
+	}
+	return parseRangeExpr(expr, r)
+}
+
+// parseHExpr parses a Jenkins-style "H" token: "H" (a single value spread
+// across the field's full range), "H(lo-hi)" (a single value spread across
+// the sub-range lo-hi), or "H/step" ("*/step" with its starting offset
+// likewise spread across [0, step) instead of always landing on r.min).
+// The spread comes from hashing key with fnv64a, so the same key always
+// resolves to the same value or offset.
+func parseHExpr(expr string, r bounds, key string) (start, end, step uint, err error) {
+	if key == "" {
+		return 0, 0, 0, fmt.Errorf("cron: %q requires a jitter key, use ParseWithKey", expr)
+	}
+
+	lo, hi := r.min, r.max
+	rest := expr[1:]
+	if strings.HasPrefix(rest, "(") {
+		closeIdx := strings.IndexByte(rest, ')')
+		if closeIdx < 0 {
+			return 0, 0, 0, fmt.Errorf("cron: unclosed H(...) range: %q", expr)
+		}
+		lowAndHigh := strings.SplitN(rest[1:closeIdx], "-", 2)
+		if len(lowAndHigh) != 2 {
+			return 0, 0, 0, fmt.Errorf("cron: bad H(lo-hi) range: %q", expr)
+		}
+		if lo, err = parseIntOrName(lowAndHigh[0], r.names); err != nil {
+			return 0, 0, 0, err
+		}
+		if hi, err = parseIntOrName(lowAndHigh[1], r.names); err != nil {
+			return 0, 0, 0, err
+		}
+		if lo < r.min {
+			return 0, 0, 0, fmt.Errorf("cron: beginning of range (%d) below minimum (%d): %q", lo, r.min, expr)
+		}
+		if hi > r.max {
+			return 0, 0, 0, fmt.Errorf("cron: end of range (%d) above maximum (%d): %q", hi, r.max, expr)
+		}
+		if lo > hi {
+			return 0, 0, 0, fmt.Errorf("cron: beginning of range (%d) beyond end of range (%d): %q", lo, hi, expr)
+		}
+		rest = rest[closeIdx+1:]
+	}
+
+	hash := fnv64a(key)
+	if rest == "" {
+		value := lo + uint(hash%uint64(hi-lo+1))
+		return value, value, 1, nil
+	}
+
+	stepStr := strings.TrimPrefix(rest, "/")
+	if stepStr == rest {
+		return 0, 0, 0, fmt.Errorf("cron: unexpected characters after H token: %q", expr)
+	}
+	step, err = mustParseInt(stepStr)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if step == 0 {
+		return 0, 0, 0, fmt.Errorf("cron: step of range should be a positive number: %q", expr)
+	}
+	// Spread the offset over min(step, width) rather than step alone: when
+	// step is wider than the range (e.g. "H(0-23)/30"), spreading over the
+	// full step could put lo+offset above hi, leaving the "for i := start;
+	// i <= end; i += step" loop in parseField with nothing to set and the
+	// field's bitmap empty. Bounding the offset to the range width still
+	// lands on a single value in that case, the same guarantee the
+	// ordinary "*/step" path gets for free by always starting at r.min.
+	width := hi - lo + 1
+	spread := step
+	if spread > width {
+		spread = width
+	}
+	offset := uint(hash % uint64(spread))
+	return lo + offset, hi, step, nil
+}
+
+// fnv64a returns the 64-bit FNV-1a hash of s, used to spread "H" tokens
+// deterministically across a field's range.
+func fnv64a(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// parseRangeExpr parses a single range expression (e.g. "5-10/2") against
+// bounds r, returning the inclusive [start, end] step sequence it
+// describes, and whether it was an unqualified wildcard ("*" or "?").
+func parseRangeExpr(expr string, r bounds) (start, end, step uint, isStar bool, err error) {
+	var (
+		rangeAndStep = strings.Split(expr, "/")
+		lowAndHigh   = strings.Split(rangeAndStep[0], "-")
+		singleDigit  = len(lowAndHigh) == 1
+	)
+
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		start = r.min
+		end = r.max
+		isStar = true
+	} else {
+		start, err = parseIntOrName(lowAndHigh[0], r.names)
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		switch len(lowAndHigh) {
+		case 1:
+			end = start
+		case 2:
+			end, err = parseIntOrName(lowAndHigh[1], r.names)
+			if err != nil {
+				return 0, 0, 0, false, err
+			}
+		default:
+			return 0, 0, 0, false, fmt.Errorf("cron: too many hyphens: %q", expr)
+		}
+	}
+
+	switch len(rangeAndStep) {
+	case 1:
+		step = 1
+	case 2:
+		step, err = mustParseInt(rangeAndStep[1])
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		if singleDigit {
+			end = r.max
+		}
+		if step > 1 {
+			isStar = false
+		}
+	default:
+		return 0, 0, 0, false, fmt.Errorf("cron: too many slashes: %q", expr)
+	}
+
+	if step == 0 {
+		return 0, 0, 0, false, fmt.Errorf("cron: step of range should be a positive number: %q", expr)
+	}
+	if start < r.min {
+		return 0, 0, 0, false, fmt.Errorf("cron: beginning of range (%d) below minimum (%d): %q", start, r.min, expr)
+	}
+	if end > r.max {
+		return 0, 0, 0, false, fmt.Errorf("cron: end of range (%d) above maximum (%d): %q", end, r.max, expr)
+	}
+	if start > end {
+		return 0, 0, 0, false, fmt.Errorf("cron: beginning of range (%d) beyond end of range (%d): %q", start, end, expr)
+	}
+
+	return start, end, step, isStar, nil
+}
+
+// parseIntOrName parses expr as a bare integer, falling back to names
+// (case-insensitive) for fields that accept symbolic values (e.g. "mon",
+// "dec").
+func parseIntOrName(expr string, names map[string]uint) (uint, error) {
+	if names != nil {
+		if v, ok := names[strings.ToLower(expr)]; ok {
+			return v, nil
+		}
+	}
+	return mustParseInt(expr)
+}
+
+// mustParseInt parses expr as a non-negative integer.
+func mustParseInt(expr string) (uint, error) {
+	num, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse int from %q: %s", expr, err)
+	}
+	if num < 0 {
+		return 0, fmt.Errorf("negative number (%d) not allowed: %q", num, expr)
+	}
+	return uint(num), nil
+}
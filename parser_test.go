@@ -0,0 +1,247 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+	"time"
+)
+
+func TestParseDescriptors(t *testing.T) {
+	cases := []struct {
+		spec string
+		want string // expanded 5-field equivalent
+	}{
+		{"@yearly", "0 0 1 1 *"},
+		{"@annually", "0 0 1 1 *"},
+		{"@monthly", "0 0 1 * *"},
+		{"@weekly", "0 0 * * 0"},
+		{"@daily", "0 0 * * *"},
+		{"@midnight", "0 0 * * *"},
+		{"@hourly", "0 * * * *"},
+	}
+	base := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		got, err := Parse(c.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %s", c.spec, err)
+			continue
+		}
+		want, err := Parse(c.want)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %s", c.want, err)
+		}
+		if gotNext, wantNext := got.Next(base), want.Next(base); !gotNext.Equal(wantNext) {
+			t.Errorf("Parse(%q).Next() = %s, want %s (from %q)", c.spec, gotNext, wantNext, c.want)
+		}
+	}
+}
+
+func TestParseEvery(t *testing.T) {
+	s, err := Parse("@every 1h30m")
+	if err != nil {
+		t.Fatalf("Parse(@every) returned error: %s", err)
+	}
+	cd, ok := s.(ConstantDelaySchedule)
+	if !ok {
+		t.Fatalf("Parse(@every 1h30m) = %T, want ConstantDelaySchedule", s)
+	}
+	if cd.Delay != 90*time.Minute {
+		t.Errorf("Delay = %s, want 90m", cd.Delay)
+	}
+}
+
+func TestParseEveryBadDuration(t *testing.T) {
+	if _, err := Parse("@every notaduration"); err == nil {
+		t.Error("Parse(@every notaduration) succeeded, want error")
+	}
+}
+
+func TestParseUnrecognizedDescriptor(t *testing.T) {
+	if _, err := Parse("@fortnightly"); err == nil {
+		t.Error("Parse(@fortnightly) succeeded, want error")
+	}
+}
+
+func TestParseCronTZPrefix(t *testing.T) {
+	s, err := Parse("CRON_TZ=America/Chicago 30 9 * * 1-5")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+	spec := s.(*SpecSchedule)
+	if spec.Location.String() != "America/Chicago" {
+		t.Errorf("Location = %s, want America/Chicago", spec.Location)
+	}
+}
+
+func TestParseLegacyTZPrefix(t *testing.T) {
+	s, err := Parse("TZ=America/Chicago 30 9 * * 1-5")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+	spec := s.(*SpecSchedule)
+	if spec.Location.String() != "America/Chicago" {
+		t.Errorf("Location = %s, want America/Chicago", spec.Location)
+	}
+}
+
+func TestParseBadTZPrefix(t *testing.T) {
+	if _, err := Parse("CRON_TZ=Not/AZone 30 9 * * 1-5"); err == nil {
+		t.Error("Parse() with a bad zone succeeded, want error")
+	}
+}
+
+func TestSecondsOptionalFieldCounts(t *testing.T) {
+	base := time.Date(2026, time.March, 1, 0, 0, 59, 0, time.UTC)
+	cases := []string{
+		"30 9 * * *",    // 5 fields
+		"15 30 9 * * *", // 6 fields
+		"15 30 9 * * * 2026-2030",
+	}
+	for _, spec := range cases {
+		if _, err := SecondsOptional.Parse(spec); err != nil {
+			t.Errorf("SecondsOptional.Parse(%q) returned error: %s", spec, err)
+		}
+	}
+
+	// A 6-field spec with an explicit Second actually fires on it.
+	s, err := SecondsOptional.Parse("15 30 9 * * *")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+	got := s.Next(base)
+	if got.Second() != 15 || got.Minute() != 30 || got.Hour() != 9 {
+		t.Errorf("Next() = %s, want 09:30:15", got)
+	}
+}
+
+func TestSecondsOptionalBadFieldCount(t *testing.T) {
+	if _, err := SecondsOptional.Parse("* * *"); err == nil {
+		t.Error("SecondsOptional.Parse() with 3 fields succeeded, want error")
+	}
+}
+
+func TestStandardRejectsSeconds(t *testing.T) {
+	if _, err := Standard.Parse("15 30 9 * * *"); err == nil {
+		t.Error("Standard.Parse() with a seconds field succeeded, want error")
+	}
+}
+
+func TestNewParserDowOptional(t *testing.T) {
+	p := NewParser(Minute | Hour | Dom | Month | Dow | DowOptional)
+	if _, err := p.Parse("30 9 * *"); err != nil {
+		t.Errorf("Parse() without Dow returned error: %s", err)
+	}
+	if _, err := p.Parse("30 9 * * 1"); err != nil {
+		t.Errorf("Parse() with Dow returned error: %s", err)
+	}
+	if _, err := p.Parse("30 9 *"); err == nil {
+		t.Error("Parse() with too few fields succeeded, want error")
+	}
+}
+
+func TestNewParserYearOnly(t *testing.T) {
+	p := NewParser(Minute | Hour | Dom | Month | Dow | Year)
+	s, err := p.Parse("30 9 1 1 * 2027")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %s", err)
+	}
+	got := s.Next(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2027, time.January, 1, 9, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestParseWithKeyRequiresKey(t *testing.T) {
+	if _, err := Parse("H H * * *"); err == nil {
+		t.Error("Parse() of an H token without ParseWithKey succeeded, want error")
+	}
+	if _, err := ParseWithKey("H H * * *", ""); err == nil {
+		t.Error("ParseWithKey() with an empty key succeeded, want error")
+	}
+}
+
+func TestParseWithKeyDeterministic(t *testing.T) {
+	s1, err := ParseWithKey("H H * * *", "worker-1")
+	if err != nil {
+		t.Fatalf("ParseWithKey() returned error: %s", err)
+	}
+	s2, err := ParseWithKey("H H * * *", "worker-1")
+	if err != nil {
+		t.Fatalf("ParseWithKey() returned error: %s", err)
+	}
+	base := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if got1, got2 := s1.Next(base), s2.Next(base); !got1.Equal(got2) {
+		t.Errorf("two ParseWithKey() calls with the same key disagree: %s vs %s", got1, got2)
+	}
+}
+
+func TestParseWithKeyDistinctKeysSpread(t *testing.T) {
+	s1, err := ParseWithKey("H H(0-7) * * *", "worker-1")
+	if err != nil {
+		t.Fatalf("ParseWithKey() returned error: %s", err)
+	}
+	s2, err := ParseWithKey("H H(0-7) * * *", "worker-2")
+	if err != nil {
+		t.Fatalf("ParseWithKey() returned error: %s", err)
+	}
+	spec1, spec2 := s1.(*SpecSchedule), s2.(*SpecSchedule)
+	if spec1.Hour == spec2.Hour && spec1.Minute == spec2.Minute {
+		t.Errorf("distinct keys resolved to the same minute/hour: %#v vs %#v", spec1, spec2)
+	}
+	if bits.OnesCount64(spec1.Hour&^(1<<wildcardBit)) != 1 {
+		t.Errorf("H(0-7) resolved to more than one hour: %#v", spec1)
+	}
+	for h := 8; h <= 23; h++ {
+		if spec1.Hour&(1<<uint(h)) != 0 {
+			t.Errorf("H(0-7) resolved to hour %d, outside of the 0-7 range", h)
+		}
+	}
+}
+
+func TestParseWithKeyHStep(t *testing.T) {
+	s, err := ParseWithKey("H/15 * * * *", "worker-1")
+	if err != nil {
+		t.Fatalf("ParseWithKey() returned error: %s", err)
+	}
+	spec := s.(*SpecSchedule)
+	minutes := []uint{}
+	for m := uint(0); m < 60; m++ {
+		if spec.Minute&(1<<m) != 0 {
+			minutes = append(minutes, m)
+		}
+	}
+	if len(minutes) != 4 {
+		t.Fatalf("H/15 resolved to %d minutes, want 4: %v", len(minutes), minutes)
+	}
+	offset := minutes[0]
+	if offset >= 15 {
+		t.Errorf("H/15's first minute offset %d is not < 15", offset)
+	}
+	for i, m := range minutes {
+		if want := offset + uint(i)*15; m != want {
+			t.Errorf("minutes[%d] = %d, want %d", i, m, want)
+		}
+	}
+}
+
+func TestParseWithKeyHStepWiderThanRange(t *testing.T) {
+	// "H(0-23)/30" has a step wider than its 24-value range, so only one
+	// value can ever match; that value must still fall inside [0, 23] for
+	// every key, never leaving the Hour bitmap empty.
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("worker-%d", i)
+		s, err := ParseWithKey("0 H(0-23)/30 * * *", key)
+		if err != nil {
+			t.Fatalf("ParseWithKey(%q) returned error: %s", key, err)
+		}
+		spec := s.(*SpecSchedule)
+		if spec.Hour == 0 {
+			t.Fatalf("key %q resolved to an empty Hour bitmap", key)
+		}
+		if bits.OnesCount64(spec.Hour) != 1 {
+			t.Errorf("key %q resolved to %d hours, want 1: %#b", key, bits.OnesCount64(spec.Hour), spec.Hour)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package cron
+
+import "time"
+
+// Schedule describes a job's duty cycle in a direction-agnostic way.
+// For implementations anchored to a fixed activation grid (e.g.
+// SpecSchedule), Next and Prev must agree on the same set of activation
+// instants, just walked in opposite directions, so callers (e.g.
+// backfill/catch-up logic recovering after downtime) can work against
+// either implementation without caring which one they hold. An
+// implementation with no such grid (e.g. ConstantDelaySchedule) should
+// document how its Next/Prev relate instead.
+type Schedule interface {
+	// Next returns the next activation time strictly after the given
+	// time, or the zero time if none can be found within a reasonable
+	// horizon.
+	Next(time.Time) time.Time
+
+	// Prev returns the previous activation time strictly before the
+	// given time, or the zero time if none can be found within a
+	// reasonable horizon.
+	Prev(time.Time) time.Time
+}
@@ -1,18 +1,41 @@
 package cron
 
 import (
-	"math/big"
+	"math/bits"
 	"strconv"
 	"time"
 )
 
 // SpecSchedule specifies a duty cycle (to the second granularity), based on a
-// traditional crontab specification. It is computed initially and stored as bit sets.
+// traditional crontab specification. Second, Minute, Hour, Dom, Month, and
+// Dow are stored as uint64 bitmaps (bit i set means value i is allowed);
+// Year spans 1970-2099 so it needs three uint64 words instead of one.
+// Representing fields this way (rather than as *big.Int) lets Next/Prev
+// jump straight to the next allowed value with a single TrailingZeros64/
+// LeadingZeros64 scan instead of testing one candidate value at a time.
 type SpecSchedule struct {
-	Second, Minute, Hour, Dom, Month, Dow, Year *big.Int
+	Second, Minute, Hour, Dom, Month, Dow uint64
+	Year                                  [3]uint64
 
 	// Override location for this schedule.
 	Location *time.Location
+
+	// Quartz-style day-of-month/day-of-week operators. A bitmap can only
+	// express a fixed set of day numbers, so it can't represent something
+	// relative to the length of a particular month (the last day, the nth
+	// weekday, ...). These are resolved against t.Year()/t.Month() on
+	// demand by dayMatches instead. The "Has*" flags distinguish "not
+	// configured" from the zero value of the field they guard.
+	HasDomLast        bool
+	DomLastOffset     int // "L" (0) or "L-n" (n days before the last day of the month)
+	HasDomNearest     bool
+	DomNearestWeekday int // "nW": day-of-month whose nearest weekday is wanted
+	HasDowLast        bool
+	DowLastOfMonth    uint8 // "5L": weekday (0-6) whose last occurrence in the month is wanted
+	HasDowNth         bool
+	DowNthOfMonth     struct {
+		Dow, N uint8 // "MON#2": weekday (0-6) and its 1-based occurrence in the month
+	}
 }
 
 // bounds provides a range of acceptable values (plus a map of name to value).
@@ -61,28 +84,136 @@ func init() {
 }
 
 const (
-	maxBits = 160
+	// wildcardBit is set in a field's bitmap when the field was an
+	// unrestricted "*" or "?", so dayMatches can tell a Dom or Dow wildcard
+	// apart from an explicit value range. It sits above every real field
+	// value (the widest, Second and Minute, only use bits 0-59), so it
+	// never collides with one.
+	wildcardBit = 63
 
 	minYear = 1970
 	maxYear = 2099
 )
 
-// Next returns the next time this schedule is activated, greater than the given
-// time.  If no time can be found to satisfy the schedule, return the zero time.
+// nextSetBit returns the position of the lowest set bit in mask at or
+// after from, scanning only bits [from, 64). ok is false if mask has no
+// set bit in that range.
+func nextSetBit(mask uint64, from uint) (uint, bool) {
+	if from >= 64 {
+		return 0, false
+	}
+	shifted := mask >> from
+	if shifted == 0 {
+		return 0, false
+	}
+	return from + uint(bits.TrailingZeros64(shifted)), true
+}
+
+// prevSetBit returns the position of the highest set bit in mask at or
+// before from, scanning only bits [0, from]. ok is false if mask has no
+// set bit in that range.
+func prevSetBit(mask uint64, from uint) (uint, bool) {
+	if from >= 64 {
+		from = 63
+	}
+	shifted := mask << (63 - from)
+	if shifted == 0 {
+		return 0, false
+	}
+	return from - uint(bits.LeadingZeros64(shifted)), true
+}
+
+// yearBit reports whether offset (a year minus minYear) is set in years.
+func yearBit(years [3]uint64, offset uint) bool {
+	return years[offset/64]&(1<<(offset%64)) != 0
+}
+
+// yearNextSetBit is nextSetBit for a three-word year bitmap.
+func yearNextSetBit(years [3]uint64, from uint) (uint, bool) {
+	word, bit := from/64, from%64
+	for ; word < 3; word++ {
+		if v, ok := nextSetBit(years[word], bit); ok {
+			return word*64 + v, true
+		}
+		bit = 0
+	}
+	return 0, false
+}
+
+// yearPrevSetBit is prevSetBit for a three-word year bitmap.
+func yearPrevSetBit(years [3]uint64, from uint) (uint, bool) {
+	word, bit := from/64, from%64
+	for {
+		if v, ok := prevSetBit(years[word], bit); ok {
+			return word*64 + v, true
+		}
+		if word == 0 {
+			return 0, false
+		}
+		word--
+		bit = 63
+	}
+}
+
+// Next returns the next time this schedule is activated, strictly after the
+// given time. If no time can be found within five years, the zero time is
+// returned.
 func (s *SpecSchedule) Next(t time.Time) time.Time {
-	// General approach
-	//
-	// For Month, Day, Hour, Minute, Second:
-	// Check if the time value matches.  If yes, continue to the next field.
-	// If the field doesn't match the schedule, then increment the field until it matches.
-	// While incrementing the field, a wrap-around brings it back to the beginning
-	// of the field list (since it is necessary to re-verify previous field
-	// values)
-
-	// Convert the given time into the schedule's timezone, if one is specified.
-	// Save the original timezone so we can convert back after we find a time.
-	// Note that schedules without a time zone specified (time.Local) are treated
-	// as local to the time provided.
+	return s.step(t, 1)
+}
+
+// Prev returns the previous time this schedule was activated, strictly
+// before the given time. If no time can be found within five years, the
+// zero time is returned.
+func (s *SpecSchedule) Prev(t time.Time) time.Time {
+	return s.step(t, -1)
+}
+
+// NextAfter is Next spelled out, for callers that pair it with PrevBefore
+// and want the "after/before" naming to read unambiguously at the call
+// site.
+func (s *SpecSchedule) NextAfter(t time.Time) time.Time {
+	return s.Next(t)
+}
+
+// PrevBefore is Prev spelled out, for callers that pair it with NextAfter.
+func (s *SpecSchedule) PrevBefore(t time.Time) time.Time {
+	return s.Prev(t)
+}
+
+// Between returns every activation of the schedule in the half-open
+// interval [start, end), in ascending order. It's the building block for
+// backfill/catch-up logic: a scheduler recovering after downtime can
+// replay Between(lastSeen, time.Now()) instead of looping Next itself.
+func (s *SpecSchedule) Between(start, end time.Time) []time.Time {
+	var times []time.Time
+	for t := s.Next(start.Add(-time.Nanosecond)); !t.IsZero() && t.Before(end); t = s.Next(t) {
+		times = append(times, t)
+	}
+	return times
+}
+
+// step walks t to the nearest strictly-later (dir == 1) or strictly-earlier
+// (dir == -1) instant that satisfies the schedule. Next and Prev are thin
+// wrappers around this in opposite directions, sharing one field-by-field
+// search instead of maintaining two hand-inverted copies.
+//
+// General approach, for Year, Month, Day, Hour, Minute, Second, from
+// coarsest to finest:
+// Check if the time value matches. If yes, continue to the next field.
+// If the field doesn't match the schedule, step it in the search direction
+// until it matches. The first time any field has to step, every
+// finer-resolution field below it is reset to its boundary value (0 going
+// forward, its max going backward), since whatever value it held in the
+// input time is no longer meaningful once a coarser field changes.
+// Stepping a field across its own boundary (e.g. December -> January, or
+// the reverse) means the field above it also needs to move, so control
+// jumps back to WRAP to re-verify every field from the top.
+func (s *SpecSchedule) step(t time.Time, dir int) time.Time {
+	// Convert the given time into the schedule's timezone, if one is
+	// specified. Save the original timezone so we can convert back after
+	// we find a time. Note that schedules without a time zone specified
+	// (time.Local) are treated as local to the time provided.
 	origLocation := t.Location()
 	loc := s.Location
 	if loc == time.Local {
@@ -92,297 +223,351 @@ func (s *SpecSchedule) Next(t time.Time) time.Time {
 		t = t.In(s.Location)
 	}
 
-	// Start at the earliest possible time (the upcoming second).
-	t = t.Add(-1*time.Second - time.Duration(t.Nanosecond())*time.Nanosecond)
+	// Start at the nearest second strictly in the search direction. Going
+	// forward, the next whole second after t always works whether or not
+	// t itself falls exactly on a second (ceil(t)+1s >= floor(t)+1s > t).
+	// Going backward, truncating t's nanoseconds is only strictly less
+	// than t when t had a fractional second to drop; an exact second
+	// needs a full second subtracted instead, or it would equal rather
+	// than precede t.
+	if dir > 0 {
+		t = t.Add(time.Second - time.Duration(t.Nanosecond())*time.Nanosecond)
+	} else if t.Nanosecond() == 0 {
+		t = t.Add(-time.Second)
+	} else {
+		t = t.Add(-time.Duration(t.Nanosecond()) * time.Nanosecond)
+	}
 
-	// This flag indicates whether a field has been incremented.
+	// This flag indicates whether a field has been stepped, in which case
+	// finer fields below it have already been reset to their boundary.
 	added := false
 
 	// If no time is found within five years, return zero.
-	yearLimit := t.Year() + 5
+	yearLimit := t.Year() + dir*5
 
 WRAP:
-	if t.Year() > yearLimit || t.Year() > maxYear {
+	if yearOutOfRange(t, dir, yearLimit) {
 		return time.Time{}
 	}
 
-	for t.Year() < minYear || s.Year.Bit(t.Year()-minYear) == 0 {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+	if offset := uint(t.Year() - minYear); t.Year() < minYear || t.Year() > maxYear || !yearBit(s.Year, offset) {
+		added = true
+		target, ok := seekYear(s.Year, t.Year(), dir)
+		if !ok {
+			return time.Time{}
 		}
-		t = t.AddDate(1, 0, 0)
-		if t.Year() > yearLimit || t.Year() > maxYear {
+		t = yearBoundary(int(target), dir, loc)
+		if yearOutOfRange(t, dir, yearLimit) {
 			return time.Time{}
 		}
 	}
 
 	// Find the first applicable month.
-	// If it's this month, then do nothing.
-	for s.Month.Bit(int(t.Month())) == 0 {
-		// If we have to add a month, reset the other parts to 0.
-		if !added {
-			added = true
-			// Otherwise, set the date at the beginning (since the current time is irrelevant).
-			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, 1, 0)
-
-		// Wrapped around.
-		if t.Month() == time.January {
+	if s.Month&(1<<uint(t.Month())) == 0 {
+		added = true
+		target, ok := seekBit(s.Month, int(t.Month()), dir)
+		if !ok {
+			// No month in this year matches; move to the adjoining year
+			// and re-verify everything from the top.
+			t = stepYear(t, dir, loc)
 			goto WRAP
 		}
+		t = monthBoundary(t.Year(), time.Month(target), dir, loc)
 	}
 
 	// Now get a day in that month.
 	//
-	// NOTE: This causes issues for daylight savings regimes where midnight does
-	// not exist.  For example: Sao Paulo has DST that transforms midnight on
-	// 11/3 into 1am. Handle that by noticing when the Hour ends up != 0.
+	// NOTE: This causes issues for daylight savings regimes where midnight
+	// does not exist. For example: Sao Paulo has DST that transforms
+	// midnight on 11/3 into 1am. Handle that by snapping back to the
+	// day's boundary hour whenever DST moves us off of it.
 	for !dayMatches(s, t) {
 		if !added {
 			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, 0, 1)
-		// Notice if the hour is no longer midnight due to DST.
-		// Add an hour if it's 23, subtract an hour if it's 1.
-		if t.Hour() != 0 {
-			if t.Hour() > 12 {
-				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
-			} else {
-				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
-			}
+			t = dayBoundary(t, dir, loc)
 		}
+		prevMonth := t.Month()
+		t = t.AddDate(0, 0, dir)
+		t = snapToHour(t, dayBoundaryHour(dir))
 
-		if t.Day() == 1 {
+		// Wrapped around into a new month.
+		if t.Month() != prevMonth {
 			goto WRAP
 		}
 	}
 
-	for s.Hour.Bit(t.Hour()) == 0 {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
-		}
-		t = t.Add(1 * time.Hour)
-
-		if t.Hour() == 0 {
+	if s.Hour&(1<<uint(t.Hour())) == 0 {
+		added = true
+		target, ok := seekBit(s.Hour, t.Hour(), dir)
+		if !ok {
+			// No hour in this day matches; move to the boundary hour of
+			// the adjoining day. That boundary is a wall-clock
+			// construction (dayBoundaryHour on a fresh date), so it can
+			// land on a DST gap the same way the day-of-month loop above
+			// can; snapToHour corrects it the same way.
+			t = t.AddDate(0, 0, dir)
+			t = time.Date(t.Year(), t.Month(), t.Day(), dayBoundaryHour(dir), minuteSecondBoundary(dir), minuteSecondBoundary(dir), 0, loc)
+			t = snapToHour(t, dayBoundaryHour(dir))
 			goto WRAP
 		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), int(target), minuteSecondBoundary(dir), minuteSecondBoundary(dir), 0, loc)
 	}
 
-	for s.Minute.Bit(t.Minute()) == 0 {
-		if !added {
-			added = true
-			t = t.Truncate(time.Minute)
-		}
-		t = t.Add(1 * time.Minute)
-
-		if t.Minute() == 0 {
+	if s.Minute&(1<<uint(t.Minute())) == 0 {
+		added = true
+		target, ok := seekBit(s.Minute, t.Minute(), dir)
+		if !ok {
+			// No minute in this hour matches; move to the adjoining hour.
+			// Unlike the Hour-field fallback above, this needs no DST-gap
+			// correction: it
+			// reads Hour back from a time produced by Add (which always
+			// lands on an instant that exists), rather than reconstructing
+			// a fixed wall-clock boundary across a day change.
+			t = t.Add(time.Duration(dir) * time.Hour)
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), minuteSecondBoundary(dir), minuteSecondBoundary(dir), 0, loc)
 			goto WRAP
 		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), int(target), minuteSecondBoundary(dir), 0, loc)
 	}
 
-	for s.Second.Bit(t.Second()) == 0 {
-		if !added {
-			added = true
-			t = t.Truncate(time.Second)
-		}
-		t = t.Add(1 * time.Second)
-
-		if t.Second() == 0 {
+	if s.Second&(1<<uint(t.Second())) == 0 {
+		target, ok := seekBit(s.Second, t.Second(), dir)
+		if !ok {
+			// No second in this minute matches; move to the adjoining
+			// minute. Safe for the same reason as the minute fallback
+			// above: Hour and Minute come back from a time produced by
+			// Add, not a reconstructed boundary.
+			t = t.Add(time.Duration(dir) * time.Minute)
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), minuteSecondBoundary(dir), 0, loc)
 			goto WRAP
 		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), int(target), 0, loc)
 	}
 
 	return t.In(origLocation)
 }
 
-// Prev returns the Prev time this schedule is activated, greater than the given
-// time.  If no time can be found to satisfy the schedule, return the zero time.
-func (s *SpecSchedule) Prev(t time.Time) time.Time {
-	// General approach
-	//
-	// For Month, Day, Hour, Minute, Second:
-	// Check if the time value matches.  If yes, continue to the next field.
-	// If the field doesn't match the schedule, then increment the field until it matches.
-	// While incrementing the field, a wrap-around brings it back to the beginning
-	// of the field list (since it is necessary to re-verify previous field
-	// values)
-
-	// Convert the given time into the schedule's timezone, if one is specified.
-	// Save the original timezone so we can convert back after we find a time.
-	// Note that schedules without a time zone specified (time.Local) are treated
-	// as local to the time provided.
-	origLocation := t.Location()
-	loc := s.Location
-	if loc == time.Local {
-		loc = t.Location()
+// seekBit finds the nearest value satisfying mask, strictly in the search
+// direction away from current: the lowest set bit above current (dir > 0)
+// or the highest set bit below current (dir < 0). ok is false if no bit in
+// mask qualifies.
+func seekBit(mask uint64, current int, dir int) (uint, bool) {
+	if dir > 0 {
+		return nextSetBit(mask, uint(current)+1)
 	}
-	if s.Location != time.Local {
-		t = t.In(s.Location)
-	}
-
-	// Start at the earliest possible time (the upcoming second).
-	// t = t.Add(1*time.Second - time.Duration(t.Nanosecond())*time.Nanosecond)
-
-	// This flag indicates whether a field has been incremented.
-	added := false
-
-	// If no time is found within five years, return zero.
-	yearLimit := t.Year() - 5
-
-	addYear := false
-	addMonth := false
-	addDay := false
-	addHour := false
-	addMinute := false
-
-WRAP:
-	if t.Year() < yearLimit || t.Year() < minYear {
-		return time.Time{}
+	if current == 0 {
+		return 0, false
 	}
+	return prevSetBit(mask, uint(current)-1)
+}
 
-	for t.Year() < minYear || s.Year.Bit(t.Year()-minYear) == 0 {
-		addYear = true
-		if !added {
-			added = true
-			t = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+// seekYear finds the nearest year (expressed as a full year number, not a
+// minYear-relative offset) satisfying years, strictly in the search
+// direction away from current.
+func seekYear(years [3]uint64, current int, dir int) (uint, bool) {
+	if dir > 0 {
+		from := uint(0)
+		if current >= minYear {
+			from = uint(current-minYear) + 1
 		}
-		t = t.AddDate(-1, 0, 0)
-		if t.Year() < yearLimit || t.Year() < minYear {
-			return time.Time{}
+		offset, ok := yearNextSetBit(years, from)
+		if !ok {
+			return 0, false
 		}
+		return uint(minYear) + offset, true
 	}
-	if addYear {
-		addYear = false
-		t = t.AddDate(1, 0, 0)
-		t = t.AddDate(0, -1, 0)
+	if current <= minYear {
+		return 0, false
 	}
+	offset, ok := yearPrevSetBit(years, uint(current-minYear)-1)
+	if !ok {
+		return 0, false
+	}
+	return uint(minYear) + offset, true
+}
 
-	// Find the first applicable month.
-	// If it's this month, then do nothing.
-
-	for s.Month.Bit(int(t.Month())) == 0 {
-		addMonth = true
-		// If we have to add a month, reset the other parts to 0.
-		if !added {
-			added = true
-			// Otherwise, set the date at the beginning (since the current time is irrelevant).
-			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, -1, 0)
+// yearBoundary returns January 1st, 00:00:00 (dir > 0) or December 31st,
+// 23:59:59 (dir < 0) of the given year.
+func yearBoundary(year int, dir int, loc *time.Location) time.Time {
+	if dir > 0 {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(year, time.December, 31, 23, 59, 59, 0, loc)
+}
 
-		// Wrapped around.
-		if t.Month() == time.January {
-			goto WRAP
-		}
+// monthBoundary returns the first instant of month (dir > 0) or the last
+// instant of month (dir < 0) in year.
+func monthBoundary(year int, month time.Month, dir int, loc *time.Location) time.Time {
+	if dir > 0 {
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
 	}
+	return time.Date(year, month, lastDayOfMonth(year, month), 23, 59, 59, 0, loc)
+}
 
-	if addMonth {
-		addMonth = false
-		t = t.AddDate(0, 1, 0)
-		t = t.AddDate(0, 0, -1)
+// yearOutOfRange reports whether t has moved outside of either the
+// schedule's absolute [minYear, maxYear] range or the five-year search
+// window in the given direction.
+func yearOutOfRange(t time.Time, dir int, yearLimit int) bool {
+	if t.Year() < minYear || t.Year() > maxYear {
+		return true
+	}
+	if dir > 0 {
+		return t.Year() > yearLimit
 	}
+	return t.Year() < yearLimit
+}
 
-	// Now get a day in that month.
-	//
-	// NOTE: This causes issues for daylight savings regimes where midnight does
-	// not exist.  For example: Sao Paulo has DST that transforms midnight on
-	// 11/3 into 1am. Handle that by noticing when the Hour ends up != 0.
-	for !dayMatches(s, t) {
-		addDay = true
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
-		}
-		t = t.AddDate(0, 0, -1)
-		// Notice if the hour is no longer midnight due to DST.
-		// Add an hour if it's 23, subtract an hour if it's 1.
-		if t.Hour() != 0 {
-			if t.Hour() > 12 {
-				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
-			} else {
-				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
-			}
-		}
+// dayBoundaryHour is the hour value a day boundary lands on: midnight going
+// forward, or 23:59:59 of the previous instant going backward.
+func dayBoundaryHour(dir int) int {
+	if dir > 0 {
+		return 0
+	}
+	return 23
+}
 
-		if t.Day() == 1 {
-			goto WRAP
-		}
+// minuteSecondBoundary is the minute/second value a minute or second field
+// wraps through: 0 going forward, 59 going backward.
+func minuteSecondBoundary(dir int) int {
+	if dir > 0 {
+		return 0
 	}
+	return 59
+}
 
-	if addDay {
-		addDay = false
-		t = t.AddDate(0, 0, 1)
-		t = t.Add(-1 * time.Hour)
+// stepYear moves t to January 1st, 00:00:00 of the next year (dir > 0) or
+// December 31st, 23:59:59 of the previous year (dir < 0).
+func stepYear(t time.Time, dir int, loc *time.Location) time.Time {
+	if dir > 0 {
+		return time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
 	}
+	return time.Date(t.Year()-1, time.December, 31, 23, 59, 59, 0, loc)
+}
 
-	//t = t.Add(-1 * time.Hour)
-	for s.Hour.Bit(t.Hour()) == 0 {
-		addHour = true
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
-		}
-		t = t.Add(-1 * time.Hour)
+// dayBoundary resets t to the start (dir > 0) or end (dir < 0) of its
+// current day, the first time the day field needs to step.
+func dayBoundary(t time.Time, dir int, loc *time.Location) time.Time {
+	if dir > 0 {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+}
 
-		if t.Hour() == 0 {
-			goto WRAP
-		}
+// snapToHour nudges t back onto hour target (0 or 23) when a DST
+// transition has moved it off of a day boundary it was supposed to land
+// on exactly, keeping the adjustment within +/-12 hours so it corrects
+// the DST jump without undoing the day step that produced t.
+func snapToHour(t time.Time, target int) time.Time {
+	diff := target - t.Hour()
+	if diff > 12 {
+		diff -= 24
+	} else if diff < -12 {
+		diff += 24
+	}
+	if diff == 0 {
+		return t
 	}
+	return t.Add(time.Duration(diff) * time.Hour)
+}
 
-	if addHour {
-		addHour = false
-		t = t.Add(1 * time.Hour)
-		t = t.Add(-1 * time.Minute)
+// dayMatches returns true if the schedule's day-of-week and day-of-month
+// restrictions are satisfied by the given time.
+func dayMatches(s *SpecSchedule, t time.Time) bool {
+	var (
+		domMatch = s.Dom&(1<<uint(t.Day())) != 0 || domSpecialMatches(s, t)
+		dowMatch = s.Dow&(1<<uint(t.Weekday())) != 0 || dowSpecialMatches(s, t)
+	)
+	if s.Dom&(1<<wildcardBit) != 0 || s.Dow&(1<<wildcardBit) != 0 {
+		return domMatch && dowMatch
 	}
+	return domMatch || dowMatch
+}
 
-	//t = t.Add(-1 * time.Minute)
-	for s.Minute.Bit(t.Minute()) == 0 {
-		addMinute = true
-		if !added {
-			added = true
-			t = t.Truncate(time.Minute)
-		}
-		t = t.Add(-1 * time.Minute)
+// domSpecialMatches reports whether t.Day() is the day resolved by one of
+// the schedule's Quartz-style day-of-month operators (L, L-n, nW), if any
+// are configured.
+func domSpecialMatches(s *SpecSchedule, t time.Time) bool {
+	if s.HasDomLast && t.Day() == lastDayOfMonth(t.Year(), t.Month())-s.DomLastOffset {
+		return true
+	}
+	if s.HasDomNearest && t.Day() == nearestWeekdayOfMonth(t.Year(), t.Month(), s.DomNearestWeekday) {
+		return true
+	}
+	return false
+}
 
-		if t.Minute() == 0 {
-			goto WRAP
+// dowSpecialMatches reports whether t.Day() is the day resolved by one of
+// the schedule's Quartz-style day-of-week operators (5L, MON#2), if any
+// are configured.
+func dowSpecialMatches(s *SpecSchedule, t time.Time) bool {
+	if s.HasDowLast && t.Day() == lastWeekdayOfMonth(t.Year(), t.Month(), time.Weekday(s.DowLastOfMonth)) {
+		return true
+	}
+	if s.HasDowNth {
+		if day, ok := nthWeekdayOfMonth(t.Year(), t.Month(), time.Weekday(s.DowNthOfMonth.Dow), int(s.DowNthOfMonth.N)); ok && t.Day() == day {
+			return true
 		}
 	}
+	return false
+}
 
-	if addMinute {
-		addMinute = false
-		t = t.Add(1 * time.Minute)
-		t = t.Add(-1 * time.Second)
-	}
+// lastDayOfMonth returns the number of days in the given month.
+func lastDayOfMonth(year int, month time.Month) int {
+	// Day 0 of the following month is the last day of this one.
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
 
-	for s.Second.Bit(t.Second()) == 0 {
-		if !added {
-			added = true
-			t = t.Truncate(time.Second)
+// nearestWeekdayOfMonth returns the day-of-month of the weekday nearest to
+// day, without crossing into the previous or next month (the Quartz "W"
+// semantics).
+func nearestWeekdayOfMonth(year int, month time.Month, day int) int {
+	last := lastDayOfMonth(year, month)
+	if day < 1 {
+		day = 1
+	}
+	if day > last {
+		day = last
+	}
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2 // nearest weekday is the following Monday
 		}
-		t = t.Add(-1 * time.Second)
-
-		if t.Second() == 0 {
-			goto WRAP
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2 // nearest weekday is the preceding Friday
 		}
+		return day + 1
+	default:
+		return day
 	}
+}
 
-	return t.In(origLocation)
+// lastWeekdayOfMonth returns the day-of-month of the last occurrence of dow
+// in the given month.
+func lastWeekdayOfMonth(year int, month time.Month, dow time.Weekday) int {
+	last := lastDayOfMonth(year, month)
+	lastWeekday := time.Date(year, month, last, 0, 0, 0, 0, time.UTC).Weekday()
+	diff := int(lastWeekday) - int(dow)
+	if diff < 0 {
+		diff += 7
+	}
+	return last - diff
 }
 
-// dayMatches returns true if the schedule's day-of-week and day-of-month
-// restrictions are satisfied by the given time.
-func dayMatches(s *SpecSchedule, t time.Time) bool {
-	var (
-		domMatch bool = s.Dom.Bit(t.Day()) > 0
-		dowMatch bool = s.Dow.Bit(int(t.Weekday())) > 0
-	)
-	if s.Dom.Bit(maxBits) > 0 || s.Dow.Bit(maxBits) > 0 {
-		return domMatch && dowMatch
+// nthWeekdayOfMonth returns the day-of-month of the nth occurrence of dow in
+// the given month. ok is false if the month doesn't have an nth occurrence
+// of dow (e.g. a 5th Monday in a month that only has four).
+func nthWeekdayOfMonth(year int, month time.Month, dow time.Weekday, n int) (day int, ok bool) {
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	diff := int(dow) - int(firstWeekday)
+	if diff < 0 {
+		diff += 7
 	}
-	return domMatch || dowMatch
+	day = 1 + diff + (n-1)*7
+	if day > lastDayOfMonth(year, month) {
+		return 0, false
+	}
+	return day, true
 }
@@ -0,0 +1,194 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, spec string) Schedule {
+	t.Helper()
+	s, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %s", spec, err)
+	}
+	return s
+}
+
+func TestQuartzLastDayOfMonth(t *testing.T) {
+	s := mustParse(t, "0 0 0 L * *")
+	got := s.Next(time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestQuartzLastDayOfMonthOffset(t *testing.T) {
+	s := mustParse(t, "0 0 0 L-3 * *")
+	got := s.Next(time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2026, time.April, 27, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestQuartzNearestWeekday(t *testing.T) {
+	// 2026-08-01 is a Saturday; since day 1 can't cross back into July, the
+	// nearest weekday is the following Monday instead of the preceding Friday.
+	s := mustParse(t, "0 0 0 1W * *")
+	got := s.Next(time.Date(2026, time.July, 20, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2026, time.August, 3, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestQuartzLastWeekdayOfMonth(t *testing.T) {
+	// The last Friday of August 2026 is the 28th.
+	s := mustParse(t, "0 0 0 ? * 5L")
+	got := s.Next(time.Date(2026, time.August, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2026, time.August, 28, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestQuartzNthWeekdayOfMonth(t *testing.T) {
+	// The second Monday of August 2026 is the 10th.
+	s := mustParse(t, "0 0 0 ? * MON#2")
+	got := s.Next(time.Date(2026, time.August, 1, 0, 0, 0, 0, time.UTC))
+	want := time.Date(2026, time.August, 10, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestNextPrevRoundTrip(t *testing.T) {
+	s := mustParse(t, "30 15 10 * * *")
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	next := s.Next(start)
+	if got := s.Prev(next.Add(time.Nanosecond)); !got.Equal(next) {
+		t.Errorf("Prev(Next(t)+1ns) = %s, want %s", got, next)
+	}
+	if got := s.Next(s.Prev(next)); !got.Equal(next) {
+		t.Errorf("Next(Prev(t)) = %s, want %s", got, next)
+	}
+}
+
+func TestNextAfterPrevBeforeAliases(t *testing.T) {
+	s := mustParse(t, "0 0 12 * * *")
+	base := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := s.(*SpecSchedule).NextAfter(base), s.Next(base); !got.Equal(want) {
+		t.Errorf("NextAfter() = %s, want %s", got, want)
+	}
+	if got, want := s.(*SpecSchedule).PrevBefore(base), s.Prev(base); !got.Equal(want) {
+		t.Errorf("PrevBefore() = %s, want %s", got, want)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	s := mustParse(t, "0 0 0 * * *")
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.March, 4, 0, 0, 0, 0, time.UTC)
+	got := s.(*SpecSchedule).Between(start, end)
+	want := []time.Time{
+		time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 3, 0, 0, 0, 0, time.UTC),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Between() returned %d times, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("Between()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNextDSTGapSaoPaulo(t *testing.T) {
+	// Sao Paulo's 2018 spring-forward turned midnight on 11/4 into 1am; the
+	// Hour field's next-day fallback and its snapToHour correction keep Next
+	// from landing on that nonexistent wall-clock time.
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %s", err)
+	}
+	s := mustParse(t, "0 0 0 * * *")
+	s.(*SpecSchedule).Location = loc
+	got := s.Next(time.Date(2018, time.November, 3, 12, 0, 0, 0, loc))
+	want := time.Date(2018, time.November, 5, 0, 0, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestNextDSTGapNoMatchingHourDoesNotHang(t *testing.T) {
+	// Santiago's spring-forward skips local midnight entirely on the day
+	// after the transition (hour 0 doesn't exist), which used to make the
+	// Hour field's "no hour in this day matches" fallback reconstruct the
+	// same nonexistent instant forever. It should instead move on to the
+	// next day where midnight does exist.
+	loc, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %s", err)
+	}
+	s := mustParse(t, "0 0 0 * * *")
+	s.(*SpecSchedule).Location = loc
+
+	done := make(chan time.Time, 1)
+	go func() { done <- s.Next(time.Date(2026, time.September, 5, 12, 0, 0, 0, loc)) }()
+	select {
+	case got := <-done:
+		want := time.Date(2026, time.September, 7, 0, 0, 0, 0, loc)
+		if !got.Equal(want) {
+			t.Errorf("Next() = %s, want %s", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Next() did not return within 2s, likely hung on the DST gap")
+	}
+}
+
+func TestBitSetHelpers(t *testing.T) {
+	mask := uint64(1<<3 | 1<<10 | 1<<40)
+
+	if v, ok := nextSetBit(mask, 0); !ok || v != 3 {
+		t.Errorf("nextSetBit(mask, 0) = (%d, %v), want (3, true)", v, ok)
+	}
+	if v, ok := nextSetBit(mask, 4); !ok || v != 10 {
+		t.Errorf("nextSetBit(mask, 4) = (%d, %v), want (10, true)", v, ok)
+	}
+	if _, ok := nextSetBit(mask, 41); ok {
+		t.Error("nextSetBit(mask, 41) = ok, want no bit found")
+	}
+
+	if v, ok := prevSetBit(mask, 63); !ok || v != 40 {
+		t.Errorf("prevSetBit(mask, 63) = (%d, %v), want (40, true)", v, ok)
+	}
+	if v, ok := prevSetBit(mask, 9); !ok || v != 3 {
+		t.Errorf("prevSetBit(mask, 9) = (%d, %v), want (3, true)", v, ok)
+	}
+	if _, ok := prevSetBit(mask, 2); ok {
+		t.Error("prevSetBit(mask, 2) = ok, want no bit found")
+	}
+}
+
+func TestYearBitSetHelpers(t *testing.T) {
+	var years [3]uint64
+	years[0] |= 1 << 5   // minYear + 5
+	years[1] |= 1 << 10  // minYear + 64 + 10
+	years[2] |= 1 << 20  // minYear + 128 + 20
+
+	if v, ok := yearNextSetBit(years, 0); !ok || v != 5 {
+		t.Errorf("yearNextSetBit(years, 0) = (%d, %v), want (5, true)", v, ok)
+	}
+	if v, ok := yearNextSetBit(years, 6); !ok || v != 74 {
+		t.Errorf("yearNextSetBit(years, 6) = (%d, %v), want (74, true)", v, ok)
+	}
+	if v, ok := yearPrevSetBit(years, 150); !ok || v != 148 {
+		t.Errorf("yearPrevSetBit(years, 150) = (%d, %v), want (148, true)", v, ok)
+	}
+	if _, ok := yearPrevSetBit(years, 4); ok {
+		t.Error("yearPrevSetBit(years, 4) = ok, want no bit found")
+	}
+}